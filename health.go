@@ -0,0 +1,70 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"net/http"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// ready is 1 once the server has completed enough startup work (an
+// initial poll cycle, or a passing self-test) to receive real traffic.
+// It backs /readyz.
+var ready int32
+
+// markReady marks the process ready for /readyz. Safe to call more than
+// once or from multiple goroutines.
+func markReady() {
+	atomic.StoreInt32(&ready, 1)
+}
+
+// handleHealthz implements Kubernetes liveness: 200 as soon as the
+// process is up and serving.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleReadyz implements Kubernetes readiness: 200 only once markReady
+// has been called.
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&ready) == 0 {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// versionInfo is the JSON body served by handleVersion.
+type versionInfo struct {
+	GoVersion string `json:"goVersion"`
+	Main      string `json:"main,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+}
+
+// handleVersion reports build info gathered via runtime/debug, so a
+// running pod can be matched back to the commit it was built from.
+func handleVersion(w http.ResponseWriter, r *http.Request) {
+	var info versionInfo
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		info.GoVersion = bi.GoVersion
+		info.Main = bi.Main.Path
+		for _, s := range bi.Settings {
+			if s.Key == "vcs.revision" {
+				info.Revision = s.Value
+			}
+		}
+	}
+	writeJSON(w, info)
+}
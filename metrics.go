@@ -0,0 +1,122 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Prometheus collectors mirroring the expvar counters in outyet.go. Both
+// backends are updated from the same recordX helpers so ServeHTTP and
+// poll never need to know which one is active.
+var (
+	hitsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outyet_http_requests_total",
+		Help: "Total HTTP requests served, by response code and template.",
+	}, []string{"code", "template"})
+
+	pollsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outyet_poll_total",
+		Help: "Total release feed polls attempted.",
+	})
+
+	pollErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "outyet_poll_errors_total",
+		Help: "Total release feed polls that failed.",
+	})
+
+	pollDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name: "outyet_poll_duration_seconds",
+		Help: "Time spent checking whether a version is tagged.",
+	})
+
+	versionTagged = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "outyet_go_version_tagged",
+		Help: "Set to 1 for each Go version listed in the release feed; versions not in the feed have no series.",
+	}, []string{"version"})
+
+	sourceLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "outyet_tag_source_latency_seconds",
+		Help: "Latency of each TagSource's Tagged check.",
+	}, []string{"source"})
+
+	sourceErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "outyet_tag_source_errors_total",
+		Help: "Total errors encountered while querying each TagSource.",
+	}, []string{"source"})
+)
+
+func init() {
+	prometheus.MustRegister(hitsTotal, pollsTotal, pollErrorsTotal, pollDuration, versionTagged,
+		sourceLatency, sourceErrorsTotal)
+}
+
+// recordHit updates the hit counters shared by expvar and Prometheus.
+func recordHit(code int, template string) {
+	hitCount.Add(1)
+	hitsTotal.WithLabelValues(strconv.Itoa(code), template).Inc()
+}
+
+// recordPoll updates the poll counters shared by expvar and Prometheus and
+// returns a func that records how long the poll took; callers defer it.
+func recordPoll() func() {
+	pollCount.Add(1)
+	pollsTotal.Inc()
+	start := time.Now()
+	return func() {
+		pollDuration.Observe(time.Since(start).Seconds())
+	}
+}
+
+// recordPollError updates the poll error counters shared by expvar and
+// Prometheus.
+func recordPollError(err error) {
+	log.Print(err)
+	pollError.Set(err.Error())
+	pollErrorCount.Add(1)
+	pollErrorsTotal.Inc()
+}
+
+// recordVersionTagged updates the per-version tagged gauge.
+func recordVersionTagged(version string, tagged bool) {
+	v := 0.0
+	if tagged {
+		v = 1
+	}
+	versionTagged.WithLabelValues(version).Set(v)
+}
+
+// recordSourceLatency records how long a TagSource took to answer.
+func recordSourceLatency(source string, d time.Duration) {
+	sourceLatency.WithLabelValues(source).Observe(d.Seconds())
+}
+
+// recordSourceError records a TagSource error.
+func recordSourceError(source string) {
+	sourceErrorsTotal.WithLabelValues(source).Inc()
+}
+
+// metricsServer returns an *http.Server exposing Prometheus metrics at
+// /metrics on addr. The existing /debug/vars stays on the main listener.
+func metricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return &http.Server{Addr: addr, Handler: mux}
+}
@@ -16,56 +16,103 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"expvar"
 	"flag"
-	"fmt"
 	"html/template"
 	"log"
-	"net"
 	"net/http"
-	"sync"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
 // Command-line flags.
 var (
-	httpAddr   = flag.String("http", ":8080", "Listen address")
-	pollPeriod = flag.Duration("poll", 5*time.Second, "Poll period")
-	version    = flag.String("version", "1.9.0", "Go version")
-	iphost     = flag.String("iphost", "127.0.0.1", "IP Host")
+	httpAddr        = flag.String("http", ":8080", "Listen address")
+	metricsAddr     = flag.String("metrics-address", "", "Prometheus metrics listen address (disabled if empty)")
+	pollPeriod      = flag.Duration("poll", 5*time.Second, "Poll period")
+	version         = flag.String("version", "go1.21.6", "Go version")
+	selftest        = flag.Bool("selftest", false, "Run self-tests and exit, without serving traffic")
+	selftestOnStart = flag.Bool("selftest-on-start", false, "Run self-tests once at startup before serving; exit if they fail")
+	shutdownTimeout = flag.Duration("shutdown-timeout", 10*time.Second, "Time to wait for in-flight requests and polls to drain on SIGTERM/SIGINT")
+	tagSources      sourceNames
 )
 
-const baseChangeURL = "https://go.googlesource.com/go/+/"
+func init() {
+	flag.Var(&tagSources, "source", "Tag source to query, in order (gerrit, github, git-ls-remote); repeatable. Unset, the tracked version's status comes from the go.dev/dl-backed Tracker instead.")
+}
 
 func main() {
 	flag.Parse()
-	ifaces, err := net.Interfaces()
-	var ipcheck net.IP
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if *metricsAddr != "" {
+		go func() {
+			log.Fatal(metricsServer(*metricsAddr).ListenAndServe())
+		}()
+	}
+
+	sources, err := buildTagSources(tagSources)
 	if err != nil {
-		log.Print(err)
+		log.Fatal(err)
 	}
 
-	// handle err
-	for _, i := range ifaces {
-		addrs, err := i.Addrs()
-		if err != nil {
+	tracker := NewReleaseTracker(ctx, *pollPeriod)
+	srv := NewServer(*version, tracker, sources)
+
+	if *selftest {
+		if err := srv.SelfTest(ctx); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if *selftestOnStart {
+		if err := srv.SelfTest(ctx); err != nil {
+			log.Fatal(err)
+		}
+		markReady()
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/", srv)
+	mux.HandleFunc("/api/releases", srv.handleReleases)
+	mux.HandleFunc("/api/releases/", srv.handleRelease)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.HandleFunc("/readyz", handleReadyz)
+	mux.HandleFunc("/version", handleVersion)
+
+	httpServer := &http.Server{Addr: *httpAddr, Handler: mux}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-sigCh
+		log.Print("shutting down")
+		cancel() // stop scheduling further polls
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+		defer shutdownCancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
 			log.Print(err)
 		}
-		// handle err
-		for _, addr := range addrs {
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ipcheck = v.IP
-			case *net.IPAddr:
-				ipcheck = v.IP
-			}
-			// process IP address
+		select {
+		case <-tracker.Done():
+		case <-shutdownCtx.Done():
+			log.Print("shutdown timeout exceeded waiting for the release tracker to drain")
 		}
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
 	}
-	iphost = flag.String("Ip Host", ipcheck.String(), "IP Host")
-	changeURL := fmt.Sprintf("%sgo%s", baseChangeURL, *iphost)
-	http.Handle("/", NewServer(*iphost, changeURL, *pollPeriod))
-	log.Fatal(http.ListenAndServe(*httpAddr, nil))
+	<-shutdownDone
 }
 
 // Exported variables for monitoring the server.
@@ -78,72 +125,91 @@ var (
 )
 
 // Server implements the outyet server.
-// It serves the user interface (it's an http.Handler)
-// and polls the remote repository for changes.
+// It serves the user interface (it's an http.Handler) and reports whether
+// its tracked version has been released. A Tracker keeps tabs on many
+// versions at once for the /api/releases endpoints, while an ordered
+// list of TagSources, when configured, decides whether the tracked
+// version itself is tagged.
 type Server struct {
 	version string
-	url     string
-	period  time.Duration
-
-	mu  sync.RWMutex // protects the yes variable
-	yes bool
+	tracker Tracker
+	sources []TagSource
 }
 
-// NewServer returns an initialized outyet server.
-func NewServer(version, url string, period time.Duration) *Server {
-	s := &Server{version: version, url: url, period: period}
-	go s.poll()
-	return s
+// NewServer returns an initialized outyet server that reports on version,
+// backed by tracker and, if given, sources.
+func NewServer(version string, tracker Tracker, sources []TagSource) *Server {
+	return &Server{version: version, tracker: tracker, sources: sources}
 }
 
-// poll polls the change URL for the specified period until the tag exists.
-// Then it sets the Server's yes field true and exits.
-func (s *Server) poll() {
-	for !isTagged(s.url) {
-		pollSleep(s.period)
+// taggedNow reports whether s.version has been tagged. It consults each
+// configured TagSource in order and considers the version tagged as soon
+// as any source confirms it; with no sources configured it falls back to
+// the tracker's cached release list.
+func (s *Server) taggedNow(ctx context.Context) bool {
+	for _, src := range s.sources {
+		start := time.Now()
+		ok, err := src.Tagged(ctx, s.version)
+		recordSourceLatency(src.Name(), time.Since(start))
+		if err != nil {
+			recordSourceError(src.Name())
+			continue
+		}
+		if ok {
+			return true
+		}
 	}
-	s.mu.Lock()
-	s.yes = true
-	s.mu.Unlock()
-	pollDone()
-}
-
-// Hooks that may be overridden for integration tests.
-var (
-	pollSleep = time.Sleep
-	pollDone  = func() {}
-)
-
-// isTagged makes an HTTP HEAD request to the given URL and reports whether it
-// returned a 200 OK response.
-func isTagged(url string) bool {
-	pollCount.Add(1)
-	r, err := http.Head(url)
-	if err != nil {
-		log.Print(err)
-		pollError.Set(err.Error())
-		pollErrorCount.Add(1)
-		return false
+	if len(s.sources) == 0 {
+		return s.tracker.Tagged(s.version)
 	}
-	return r.StatusCode == http.StatusOK
+	return false
 }
 
 // ServeHTTP implements the HTTP user interface.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	hitCount.Add(1)
-	s.mu.RLock()
+	recordHit(http.StatusOK, "tmpl")
 	data := struct {
-		URL     string
 		Version string
 		Yes     bool
 	}{
-		s.url,
 		s.version,
-		s.yes,
+		s.taggedNow(r.Context()),
 	}
-	s.mu.RUnlock()
-	err := tmpl.Execute(w, data)
-	if err != nil {
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Print(err)
+	}
+}
+
+// handleReleases serves GET /api/releases, optionally narrowed by the
+// os, arch and kind query parameters.
+func (s *Server) handleReleases(w http.ResponseWriter, r *http.Request) {
+	recordHit(http.StatusOK, "releases")
+	q := r.URL.Query()
+	writeJSON(w, s.tracker.Releases(q.Get("os"), q.Get("arch"), q.Get("kind")))
+}
+
+// handleRelease serves GET /api/releases/{version}.
+func (s *Server) handleRelease(w http.ResponseWriter, r *http.Request) {
+	v := strings.TrimPrefix(r.URL.Path, "/api/releases/")
+	if v == "" {
+		recordHit(http.StatusNotFound, "release")
+		http.NotFound(w, r)
+		return
+	}
+	release, ok := s.tracker.Release(v)
+	if !ok {
+		recordHit(http.StatusNotFound, "release")
+		http.Error(w, "release not found", http.StatusNotFound)
+		return
+	}
+	recordHit(http.StatusOK, "release")
+	writeJSON(w, release)
+}
+
+// writeJSON encodes v as the JSON response body.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
 		log.Print(err)
 	}
 }
@@ -153,8 +219,7 @@ var tmpl = template.Must(template.New("tmpl").Parse(`
 <!DOCTYPE html><html><body><center>
 	<img src="https://raw.githubusercontent.com/twogg-git/k8s-intro/master/kubernetes_katacoda.png" alt="Kubernetes & Katacoda" style="width:400px;height:200px;">
 	<h1 style="color:green">Playing with Kubernetes & Katacoda!</h1>
-	<h2 style="color:blue">Your server IP: {{.Version}}</h2>
-	<h3 style="color:blue">This is a fresh new version!!!</h3>	
-	<h3 style="color:blue">Rolling version [1.3-k8s}</h3>	
+	<h2 style="color:blue">Go version: {{.Version}}</h2>
+	<h3 style="color:blue">{{if .Yes}}Yes!{{else}}No :({{end}}</h3>
 </center></body></html>
 `))
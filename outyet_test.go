@@ -0,0 +1,92 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeTracker is a Tracker whose Tagged result and Refresh error are set
+// directly by tests.
+type fakeTracker struct {
+	tagged     bool
+	refreshErr error
+}
+
+func (f *fakeTracker) Releases(os, arch, kind string) []Release { return nil }
+func (f *fakeTracker) Release(version string) (Release, bool)   { return Release{}, false }
+func (f *fakeTracker) Tagged(version string) bool               { return f.tagged }
+func (f *fakeTracker) Refresh(ctx context.Context) error        { return f.refreshErr }
+
+// fakeTagSource is a TagSource whose answer and error are set directly by
+// tests, so Server.taggedNow's any-source-wins logic can be exercised
+// without any network access.
+type fakeTagSource struct {
+	name   string
+	tagged bool
+	err    error
+}
+
+func (f *fakeTagSource) Name() string { return f.name }
+func (f *fakeTagSource) Tagged(ctx context.Context, version string) (bool, error) {
+	return f.tagged, f.err
+}
+
+func TestServerTaggedNow(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name    string
+		sources []TagSource
+		tracker *fakeTracker
+		want    bool
+	}{
+		{"no sources falls back to tracker (tagged)", nil, &fakeTracker{tagged: true}, true},
+		{"no sources falls back to tracker (not tagged)", nil, &fakeTracker{tagged: false}, false},
+		{
+			"first source confirms",
+			[]TagSource{&fakeTagSource{name: "a", tagged: true}, &fakeTagSource{name: "b", tagged: false}},
+			&fakeTracker{tagged: false},
+			true,
+		},
+		{
+			"later source confirms after an earlier denies",
+			[]TagSource{&fakeTagSource{name: "a", tagged: false}, &fakeTagSource{name: "b", tagged: true}},
+			&fakeTracker{tagged: false},
+			true,
+		},
+		{
+			"an erroring source is skipped, not fatal",
+			[]TagSource{&fakeTagSource{name: "a", err: errBoom}, &fakeTagSource{name: "b", tagged: true}},
+			&fakeTracker{tagged: false},
+			true,
+		},
+		{
+			"all sources deny, tracker is not consulted",
+			[]TagSource{&fakeTagSource{name: "a", tagged: false}, &fakeTagSource{name: "b", tagged: false}},
+			&fakeTracker{tagged: true},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer("go1.21.6", tt.tracker, tt.sources)
+			if got := s.taggedNow(context.Background()); got != tt.want {
+				t.Errorf("taggedNow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+)
+
+// SelfTest exercises the server end-to-end against an in-process
+// httptest.Server: it renders the template, checks that /debug/vars
+// returns valid JSON, and performs one bounded, synchronous tracker
+// refresh. It is used both by the -selftest command, which runs the
+// checks and exits without ever calling ListenAndServe, and by
+// -selftest-on-start, which runs them once at startup before
+// ListenAndServe and exits if they fail.
+func (s *Server) SelfTest(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/", s)
+	mux.Handle("/debug/vars", expvar.Handler())
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/")
+	if err != nil {
+		return fmt.Errorf("selftest: GET /: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("selftest: GET / returned %s", resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("selftest: reading /: %w", err)
+	}
+	if !strings.Contains(string(body), s.version) {
+		return fmt.Errorf("selftest: rendered page does not mention version %q", s.version)
+	}
+
+	varsResp, err := http.Get(ts.URL + "/debug/vars")
+	if err != nil {
+		return fmt.Errorf("selftest: GET /debug/vars: %w", err)
+	}
+	defer varsResp.Body.Close()
+	var vars map[string]interface{}
+	if err := json.NewDecoder(varsResp.Body).Decode(&vars); err != nil {
+		return fmt.Errorf("selftest: /debug/vars is not valid JSON: %w", err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := s.tracker.Refresh(checkCtx); err != nil {
+		return fmt.Errorf("selftest: tracker refresh: %w", err)
+	}
+	s.taggedNow(checkCtx)
+	return nil
+}
@@ -0,0 +1,34 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestServerSelfTest(t *testing.T) {
+	s := NewServer("go1.21.6", &fakeTracker{tagged: true}, nil)
+	if err := s.SelfTest(context.Background()); err != nil {
+		t.Fatalf("SelfTest: %v", err)
+	}
+}
+
+func TestServerSelfTestTrackerRefreshError(t *testing.T) {
+	s := NewServer("go1.21.6", &fakeTracker{refreshErr: errors.New("feed unreachable")}, nil)
+	if err := s.SelfTest(context.Background()); err == nil {
+		t.Fatal("SelfTest returned nil error for a failing tracker refresh, want an error")
+	}
+}
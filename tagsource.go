@@ -0,0 +1,186 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// TagSource confirms whether a single Go version has been tagged in some
+// upstream repository. A Server may be given several; a version is
+// considered tagged as soon as any source confirms it.
+type TagSource interface {
+	// Name identifies the source for logging and metrics labels.
+	Name() string
+	// Tagged reports whether version has been tagged, bounded by ctx.
+	Tagged(ctx context.Context, version string) (bool, error)
+}
+
+// GerritTagSource probes go.googlesource.com's tag ref directly, the way
+// the original outyet example checked a single hard-coded changeURL.
+type GerritTagSource struct {
+	BaseURL string // e.g. "https://go.googlesource.com/go/+/refs/tags/"
+}
+
+// NewGerritTagSource returns a GerritTagSource pointed at the canonical
+// Go source repository.
+func NewGerritTagSource() *GerritTagSource {
+	return &GerritTagSource{BaseURL: "https://go.googlesource.com/go/+/refs/tags/"}
+}
+
+// Name implements TagSource.
+func (s *GerritTagSource) Name() string { return "gerrit" }
+
+// Tagged implements TagSource.
+func (s *GerritTagSource) Tagged(ctx context.Context, version string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.BaseURL+version, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// GitHubTagSource checks the GitHub Releases API for golang/go. It caches
+// the ETag of its last 200 response per version and sends it back as
+// If-None-Match, and treats a 404 as a terminal "not tagged" rather than
+// an error, the way cluster-api's httpGetFilesFromRelease does.
+type GitHubTagSource struct {
+	BaseURL string // e.g. "https://api.github.com/repos/golang/go/releases/tags/"
+
+	mu     sync.Mutex
+	etags  map[string]string
+	tagged map[string]bool
+}
+
+// NewGitHubTagSource returns a GitHubTagSource pointed at golang/go.
+func NewGitHubTagSource() *GitHubTagSource {
+	return &GitHubTagSource{
+		BaseURL: "https://api.github.com/repos/golang/go/releases/tags/",
+		etags:   make(map[string]string),
+		tagged:  make(map[string]bool),
+	}
+}
+
+// Name implements TagSource.
+func (s *GitHubTagSource) Name() string { return "github" }
+
+// Tagged implements TagSource.
+func (s *GitHubTagSource) Tagged(ctx context.Context, version string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.BaseURL+version, nil)
+	if err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	if etag, ok := s.etags[version]; ok {
+		req.Header.Set("If-None-Match", etag)
+	}
+	s.mu.Unlock()
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		s.mu.Lock()
+		tagged := s.tagged[version]
+		s.mu.Unlock()
+		return tagged, nil
+	case http.StatusNotFound:
+		s.mu.Lock()
+		s.tagged[version] = false
+		s.mu.Unlock()
+		return false, nil
+	case http.StatusOK:
+		s.mu.Lock()
+		s.tagged[version] = true
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			s.etags[version] = etag
+		}
+		s.mu.Unlock()
+		return true, nil
+	default:
+		return false, fmt.Errorf("github releases API returned %s", resp.Status)
+	}
+}
+
+// GitLsRemoteTagSource shells out to git ls-remote for air-gapped mirrors
+// that can reach the Go source repository over git but not HTTPS
+// JSON/REST APIs.
+type GitLsRemoteTagSource struct {
+	RepoURL string // e.g. "https://go.googlesource.com/go"
+}
+
+// NewGitLsRemoteTagSource returns a GitLsRemoteTagSource pointed at the
+// canonical Go source repository.
+func NewGitLsRemoteTagSource() *GitLsRemoteTagSource {
+	return &GitLsRemoteTagSource{RepoURL: "https://go.googlesource.com/go"}
+}
+
+// Name implements TagSource.
+func (s *GitLsRemoteTagSource) Name() string { return "git-ls-remote" }
+
+// Tagged implements TagSource.
+func (s *GitLsRemoteTagSource) Tagged(ctx context.Context, version string) (bool, error) {
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--tags", s.RepoURL, version)
+	out, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("git ls-remote: %w", err)
+	}
+	return strings.TrimSpace(string(out)) != "", nil
+}
+
+// buildTagSources resolves repeatable -source flag values into TagSource
+// implementations, in the order given. An empty list resolves to no
+// sources at all, so taggedNow falls back to the go.dev/dl-backed
+// Tracker; -source must be passed explicitly to probe Gerrit, GitHub or
+// a git mirror instead.
+func buildTagSources(names []string) ([]TagSource, error) {
+	sources := make([]TagSource, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "gerrit":
+			sources = append(sources, NewGerritTagSource())
+		case "github":
+			sources = append(sources, NewGitHubTagSource())
+		case "git-ls-remote":
+			sources = append(sources, NewGitLsRemoteTagSource())
+		default:
+			return nil, fmt.Errorf("unknown tag source %q", name)
+		}
+	}
+	return sources, nil
+}
+
+// sourceNames collects repeated -source flag values in the order given.
+type sourceNames []string
+
+func (s *sourceNames) String() string { return strings.Join(*s, ",") }
+
+func (s *sourceNames) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+)
+
+func TestGerritTagSourceTagged(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		want    bool
+		wantErr bool
+	}{
+		{"tagged", http.StatusOK, true, false},
+		{"not tagged", http.StatusNotFound, false, false},
+		{"server error", http.StatusInternalServerError, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			s := &GerritTagSource{BaseURL: ts.URL + "/"}
+			got, err := s.Tagged(context.Background(), "go1.21.6")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Tagged = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitHubTagSourceTagged(t *testing.T) {
+	const etag = `"abc123"`
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/go1.21.6":
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", etag)
+			w.WriteHeader(http.StatusOK)
+		case "/go1.99":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	s := &GitHubTagSource{BaseURL: ts.URL + "/", etags: make(map[string]string), tagged: make(map[string]bool)}
+
+	got, err := s.Tagged(context.Background(), "go1.21.6")
+	if err != nil || !got {
+		t.Fatalf("first Tagged(go1.21.6) = %v, %v, want true, nil", got, err)
+	}
+
+	got, err = s.Tagged(context.Background(), "go1.21.6")
+	if err != nil || !got {
+		t.Fatalf("cached Tagged(go1.21.6) = %v, %v, want true, nil", got, err)
+	}
+	if requests != 2 {
+		t.Fatalf("server saw %d requests, want 2 (a cached ETag still costs a conditional request)", requests)
+	}
+
+	got, err = s.Tagged(context.Background(), "go1.99")
+	if err != nil || got {
+		t.Fatalf("Tagged(go1.99) = %v, %v, want false, nil", got, err)
+	}
+
+	if _, err := s.Tagged(context.Background(), "unknown"); err == nil {
+		t.Error("Tagged(unknown) returned nil error for a 500 response, want an error")
+	}
+}
+
+func TestGitLsRemoteTagSourceTagged(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "-q", "-b", "main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "test")
+	run("commit", "--allow-empty", "-q", "-m", "init")
+	run("tag", "go1.21.6")
+
+	s := &GitLsRemoteTagSource{RepoURL: dir}
+	got, err := s.Tagged(context.Background(), "go1.21.6")
+	if err != nil {
+		t.Fatalf("Tagged(go1.21.6): %v", err)
+	}
+	if !got {
+		t.Error("Tagged(go1.21.6) = false, want true")
+	}
+
+	got, err = s.Tagged(context.Background(), "go1.99")
+	if err != nil {
+		t.Fatalf("Tagged(go1.99): %v", err)
+	}
+	if got {
+		t.Error("Tagged(go1.99) = true, want false")
+	}
+}
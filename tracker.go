@@ -0,0 +1,220 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// releasesURL is the official JSON feed of Go releases.
+const releasesURL = "https://go.dev/dl/?mode=json&include=all"
+
+// fetchTimeout bounds a single background fetch of releasesURL. It is
+// deliberately not derived from the poll loop's ctx: once a fetch is
+// in flight we let it run to completion (or this timeout) rather than
+// aborting it the instant the server starts shutting down.
+const fetchTimeout = 30 * time.Second
+
+// File describes a single downloadable artifact belonging to a Release.
+type File struct {
+	Filename string `json:"filename"`
+	OS       string `json:"os"`
+	Arch     string `json:"arch"`
+	Version  string `json:"version"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	Kind     string `json:"kind"`
+}
+
+// Release describes one Go release as reported by go.dev/dl.
+type Release struct {
+	Version string `json:"version"`
+	Stable  bool   `json:"stable"`
+	Files   []File `json:"files"`
+}
+
+// Tracker reports which Go versions have been released. Implementations
+// refresh their view of the world in the background; callers only ever
+// read the cached state, so methods must be safe for concurrent use.
+type Tracker interface {
+	// Releases returns the known releases, optionally narrowed to files
+	// matching os, arch and kind. An empty string matches any value.
+	Releases(os, arch, kind string) []Release
+	// Release looks up a single release by version, e.g. "go1.21.6".
+	Release(version string) (Release, bool)
+	// Tagged reports whether the given version has been released.
+	Tagged(version string) bool
+	// Refresh performs one synchronous, bounded fetch of the release
+	// feed, replacing the cache. It is meant for callers, such as
+	// SelfTest, that need to confirm the feed is reachable right now
+	// rather than waiting for the next background poll.
+	Refresh(ctx context.Context) error
+}
+
+// ReleaseTracker polls releasesURL on a fixed period and keeps an
+// in-memory cache of the releases it last saw.
+type ReleaseTracker struct {
+	url    string
+	period time.Duration
+	done   chan struct{}
+
+	mu       sync.RWMutex
+	releases []Release
+
+	readyOnce sync.Once
+}
+
+// NewReleaseTracker returns a ReleaseTracker that refreshes its cache every
+// period, starting immediately in the background. Polling stops, draining
+// any in-flight request, as soon as ctx is done; callers that need to wait
+// for that drain to finish (e.g. on shutdown) should block on Done.
+func NewReleaseTracker(ctx context.Context, period time.Duration) *ReleaseTracker {
+	t := &ReleaseTracker{url: releasesURL, period: period, done: make(chan struct{})}
+	go t.poll(ctx)
+	return t
+}
+
+// Done returns a channel that's closed once poll has returned: ctx is done
+// and any in-flight fetch has finished draining.
+func (t *ReleaseTracker) Done() <-chan struct{} {
+	return t.done
+}
+
+// poll refreshes the cache every period until ctx is done, then closes
+// done once any fetch still in flight has drained.
+func (t *ReleaseTracker) poll(ctx context.Context) {
+	defer close(t.done)
+	ticker := time.NewTicker(t.period)
+	defer ticker.Stop()
+	t.tick(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.tick(ctx)
+		}
+	}
+}
+
+// tick performs one poll cycle, marking the tracker ready on its first
+// success. The fetch itself runs on its own bounded context so that
+// canceling ctx never aborts an in-flight request. tick consults ctx
+// only to skip recording a failed fetch once shutdown has begun; poll
+// uses it to decide whether to schedule another tick.
+func (t *ReleaseTracker) tick(ctx context.Context) {
+	done := recordPoll()
+	fetchCtx, cancel := context.WithTimeout(context.Background(), fetchTimeout)
+	err := t.Refresh(fetchCtx)
+	cancel()
+	done()
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		recordPollError(err)
+		return
+	}
+	t.readyOnce.Do(markReady)
+}
+
+// Refresh implements Tracker.
+func (t *ReleaseTracker) Refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("releases feed returned %s", resp.Status)
+	}
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	t.releases = releases
+	t.mu.Unlock()
+	for _, r := range releases {
+		recordVersionTagged(r.Version, true)
+	}
+	return nil
+}
+
+// Releases implements Tracker.
+func (t *ReleaseTracker) Releases(os, arch, kind string) []Release {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if os == "" && arch == "" && kind == "" {
+		out := make([]Release, len(t.releases))
+		copy(out, t.releases)
+		return out
+	}
+	var out []Release
+	for _, r := range t.releases {
+		files := filterFiles(r.Files, os, arch, kind)
+		if len(files) == 0 {
+			continue
+		}
+		r.Files = files
+		out = append(out, r)
+	}
+	return out
+}
+
+// filterFiles returns the files matching os, arch and kind. An empty
+// string matches any value.
+func filterFiles(files []File, os, arch, kind string) []File {
+	var out []File
+	for _, f := range files {
+		if os != "" && f.OS != os {
+			continue
+		}
+		if arch != "" && f.Arch != arch {
+			continue
+		}
+		if kind != "" && f.Kind != kind {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// Release implements Tracker.
+func (t *ReleaseTracker) Release(version string) (Release, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, r := range t.releases {
+		if r.Version == version {
+			return r, true
+		}
+	}
+	return Release{}, false
+}
+
+// Tagged implements Tracker.
+func (t *ReleaseTracker) Tagged(version string) bool {
+	_, ok := t.Release(version)
+	return ok
+}
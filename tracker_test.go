@@ -0,0 +1,155 @@
+/*
+Copyright 2014 Google Inc.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+     http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const sampleReleasesJSON = `[
+	{
+		"version": "go1.21.6",
+		"stable": true,
+		"files": [
+			{"filename": "go1.21.6.linux-amd64.tar.gz", "os": "linux", "arch": "amd64", "kind": "archive"},
+			{"filename": "go1.21.6.darwin-arm64.tar.gz", "os": "darwin", "arch": "arm64", "kind": "archive"}
+		]
+	},
+	{
+		"version": "go1.22rc1",
+		"stable": false,
+		"files": [
+			{"filename": "go1.22rc1.linux-amd64.tar.gz", "os": "linux", "arch": "amd64", "kind": "archive"}
+		]
+	}
+]`
+
+func newTestTracker(t *testing.T, body string, status int) *ReleaseTracker {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(ts.Close)
+	return &ReleaseTracker{url: ts.URL}
+}
+
+func TestReleaseTrackerRefresh(t *testing.T) {
+	tr := newTestTracker(t, sampleReleasesJSON, http.StatusOK)
+	if err := tr.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := len(tr.Releases("", "", "")); got != 2 {
+		t.Fatalf("Releases(\"\",\"\",\"\") returned %d releases, want 2", got)
+	}
+	if !tr.Tagged("go1.21.6") {
+		t.Errorf("Tagged(\"go1.21.6\") = false, want true")
+	}
+	if tr.Tagged("go1.99") {
+		t.Errorf("Tagged(\"go1.99\") = true, want false")
+	}
+}
+
+func TestReleaseTrackerRefreshError(t *testing.T) {
+	tr := newTestTracker(t, "boom", http.StatusInternalServerError)
+	if err := tr.Refresh(context.Background()); err == nil {
+		t.Fatal("Refresh returned nil error for a 500 response, want an error")
+	}
+}
+
+func TestReleaseTrackerRelease(t *testing.T) {
+	tr := newTestTracker(t, sampleReleasesJSON, http.StatusOK)
+	if err := tr.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, ok := tr.Release("go1.21.6"); !ok {
+		t.Errorf("Release(\"go1.21.6\") not found")
+	}
+	if _, ok := tr.Release("go1.0"); ok {
+		t.Errorf("Release(\"go1.0\") found, want not found")
+	}
+}
+
+func TestReleaseTrackerPollDrainsOnShutdown(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-unblock
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("[]"))
+	}))
+	defer ts.Close()
+
+	tr := &ReleaseTracker{url: ts.URL, period: time.Hour, done: make(chan struct{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	go tr.poll(ctx)
+
+	<-started
+	cancel() // stop scheduling further ticks; the in-flight fetch should keep running
+
+	select {
+	case <-tr.Done():
+		t.Fatal("Done closed before the in-flight fetch was allowed to finish")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(unblock) // let the handler, and so Refresh, complete
+
+	select {
+	case <-tr.Done():
+	case <-time.After(2 * time.Second):
+		t.Fatal("Done did not close after the in-flight fetch finished")
+	}
+}
+
+func TestReleaseTrackerReleasesFilter(t *testing.T) {
+	tr := newTestTracker(t, sampleReleasesJSON, http.StatusOK)
+	if err := tr.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		os, arch, kind   string
+		wantVersions     []string
+		wantFilesPerEach int
+	}{
+		{"no filter", "", "", "", []string{"go1.21.6", "go1.22rc1"}, -1},
+		{"linux amd64", "linux", "amd64", "", []string{"go1.21.6", "go1.22rc1"}, 1},
+		{"darwin arm64", "darwin", "arm64", "", []string{"go1.21.6"}, 1},
+		{"unknown os", "plan9", "", "", nil, -1},
+		{"archive kind only", "", "", "archive", []string{"go1.21.6", "go1.22rc1"}, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			releases := tr.Releases(tt.os, tt.arch, tt.kind)
+			if len(releases) != len(tt.wantVersions) {
+				t.Fatalf("got %d releases, want %d", len(releases), len(tt.wantVersions))
+			}
+			for i, r := range releases {
+				if r.Version != tt.wantVersions[i] {
+					t.Errorf("release[%d].Version = %q, want %q", i, r.Version, tt.wantVersions[i])
+				}
+				if tt.wantFilesPerEach >= 0 && len(r.Files) != tt.wantFilesPerEach {
+					t.Errorf("release[%d].Files has %d entries, want %d", i, len(r.Files), tt.wantFilesPerEach)
+				}
+			}
+		})
+	}
+}